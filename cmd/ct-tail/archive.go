@@ -0,0 +1,63 @@
+package main
+
+// archive.go optionally persists each fetched CT log entry to S3 under the
+// same certs/ layout the external producer used to write, so a tailed log
+// still leaves an audit trail and can be replayed through the S3 handler.
+// When a KMS key is configured, entries are envelope-encrypted before
+// upload via ctmon.Encryptor.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/psanford/ct-to-slack/internal/ctmon"
+)
+
+const archivePrefix = "certs/"
+
+// archiver writes fetched leaf entries to S3, optionally envelope-encrypting
+// them first. A nil *archiver is valid and Put becomes a no-op, so archiving
+// can be left unconfigured without branching at every call site.
+type archiver struct {
+	s3client  *s3.Client
+	bucket    string
+	encryptor *ctmon.Encryptor
+}
+
+func (a *archiver) Put(ctx context.Context, key string, entry ct.LeafEntry) error {
+	if a == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal leaf entry: %w", err)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(archivePrefix + key),
+	}
+
+	if a.encryptor != nil {
+		enc, err := a.encryptor.Encrypt(ctx, body)
+		if err != nil {
+			return fmt.Errorf("encrypt leaf entry: %w", err)
+		}
+		body = enc.Ciphertext
+		input.Metadata = enc.Metadata
+	}
+
+	input.Body = bytes.NewReader(body)
+
+	_, err = a.s3client.PutObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("put object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+// Command ct-tail is an alternative to the S3-triggered Lambda in the
+// repository root: instead of waiting on an external producer to write CT
+// log entries into S3, it pulls entries directly from a configured list of
+// CT logs, verifies each log's consistency proof against the last checkpoint
+// before trusting new entries, and feeds the results into the same
+// parsing/notification pipeline. Run with -once from a scheduled Lambda or
+// ECS task, or leave it running as a long-lived poller.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/merkle/logverifier"
+	"github.com/google/certificate-transparency-go/merkle/rfc6962"
+	"github.com/psanford/ct-to-slack/internal/ctmon"
+	"github.com/psanford/ssmparam/v2"
+)
+
+const (
+	defaultBatchSize     = 256
+	defaultPollInterval  = 5 * time.Minute
+	checkpointTableParam = "ct_checkpoint_table"
+	defaultCheckpointTbl = "ct-to-slack-checkpoints"
+)
+
+func main() {
+	once := flag.Bool("once", false, "poll every configured log a single time and exit, instead of looping")
+	flag.Parse()
+
+	ctx := context.Background()
+	lgr := slog.With()
+
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithDisableRequestCompression(aws.Bool(true)))
+	if err != nil {
+		lgr.Error("load_aws_config_err", "err", err)
+		os.Exit(1)
+	}
+
+	kv := ssmparam.New(ssm.NewFromConfig(cfg))
+
+	watchlist, notifiers, dedup, err := ctmon.LoadConfig(kv, cfg, lgr)
+	if err != nil {
+		lgr.Error("load_config_err", "err", err)
+		os.Exit(1)
+	}
+
+	logURLs, batchSize, pollInterval, err := loadTailConfig(kv)
+	if err != nil {
+		lgr.Error("load_tail_config_err", "err", err)
+		os.Exit(1)
+	}
+
+	checkpointTable := defaultCheckpointTbl
+	if v, err := kv.Get(checkpointTableParam); err == nil && v != "" {
+		checkpointTable = v
+	}
+
+	ddb := dynamodb.NewFromConfig(cfg)
+	store := &checkpointStore{client: ddb, table: checkpointTable}
+
+	arc := loadArchiver(kv, cfg)
+
+	for {
+		for _, logURL := range logURLs {
+			if err := pollLog(ctx, lgr, store, arc, logURL, batchSize, watchlist, notifiers, dedup); err != nil {
+				lgr.Error("poll_log_err", "log", logURL, "err", err)
+			}
+		}
+
+		if *once {
+			return
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// loadTailConfig reads the CT log URLs, get-entries batch size, and poll
+// interval from SSM (ct_log_urls, ct_batch_size, ct_poll_interval_seconds).
+func loadTailConfig(kv ctmon.SSMGetter) ([]string, int64, time.Duration, error) {
+	logURLsParam, err := kv.Get("ct_log_urls")
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("get ct_log_urls: %w", err)
+	}
+	var logURLs []string
+	for _, u := range strings.Split(logURLsParam, ",") {
+		u = strings.TrimSpace(u)
+		if u != "" {
+			logURLs = append(logURLs, u)
+		}
+	}
+	if len(logURLs) == 0 {
+		return nil, 0, 0, fmt.Errorf("ct_log_urls is empty")
+	}
+
+	batchSize := int64(defaultBatchSize)
+	if v, err := kv.Get("ct_batch_size"); err == nil && v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("parse ct_batch_size: %w", err)
+		}
+		batchSize = n
+	}
+
+	pollInterval := defaultPollInterval
+	if v, err := kv.Get("ct_poll_interval_seconds"); err == nil && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("parse ct_poll_interval_seconds: %w", err)
+		}
+		pollInterval = time.Duration(n) * time.Second
+	}
+
+	return logURLs, batchSize, pollInterval, nil
+}
+
+// loadArchiver builds an optional archiver that persists fetched leaf
+// entries to S3 (envelope-encrypted if kms_key_arn is configured). It
+// returns nil, leaving archiving disabled, when ct_archive_bucket isn't set.
+func loadArchiver(kv ctmon.SSMGetter, cfg aws.Config) *archiver {
+	bucket, err := kv.Get("ct_archive_bucket")
+	if err != nil || bucket == "" {
+		return nil
+	}
+
+	arc := &archiver{s3client: s3.NewFromConfig(cfg), bucket: bucket}
+	if kekARN, err := kv.Get("kms_key_arn"); err == nil && kekARN != "" {
+		arc.encryptor = ctmon.NewEncryptor(kms.NewFromConfig(cfg), kekARN)
+	}
+	return arc
+}
+
+// pollLog advances one CT log from its last checkpointed tree size to its
+// current STH, verifying consistency before fetching and processing the
+// new entries. On the very first run for a log it checkpoints the current
+// head without processing any entries, so onboarding a log doesn't replay
+// its entire history.
+func pollLog(ctx context.Context, lgr *slog.Logger, store *checkpointStore, arc *archiver, logURL string, batchSize int64, watchlist *ctmon.Watchlist, notifiers []ctmon.Notifier, dedup *ctmon.Deduper) error {
+	logClient, err := client.New(logURL, &http.Client{Timeout: 30 * time.Second}, jsonclient.Options{})
+	if err != nil {
+		return fmt.Errorf("new ct client: %w", err)
+	}
+
+	sth, err := logClient.GetSTH(ctx)
+	if err != nil {
+		return fmt.Errorf("get-sth: %w", err)
+	}
+
+	cp, err := store.Get(ctx, logURL)
+	if err != nil {
+		return fmt.Errorf("get checkpoint: %w", err)
+	}
+
+	if cp == nil {
+		lgr.Info("onboarding_log", "log", logURL, "tree_size", sth.TreeSize)
+		return store.Put(ctx, logCheckpoint{
+			LogURL:   logURL,
+			TreeSize: int64(sth.TreeSize),
+			RootHash: sth.SHA256RootHash[:],
+		})
+	}
+
+	newSize := int64(sth.TreeSize)
+	if newSize <= cp.TreeSize {
+		return nil
+	}
+
+	proof, err := logClient.GetSTHConsistency(ctx, uint64(cp.TreeSize), sth.TreeSize)
+	if err != nil {
+		return fmt.Errorf("get-sth-consistency: %w", err)
+	}
+	verifier := logverifier.New(rfc6962.DefaultHasher)
+	if err := verifier.VerifyConsistencyProof(cp.TreeSize, newSize, cp.RootHash, sth.SHA256RootHash[:], proof); err != nil {
+		return fmt.Errorf("verify consistency proof: %w", err)
+	}
+
+	for start := cp.TreeSize; start < newSize; {
+		end := start + batchSize - 1
+		if end >= newSize {
+			end = newSize - 1
+		}
+
+		entries, err := logClient.GetRawEntries(ctx, start, end)
+		if err != nil {
+			return fmt.Errorf("get-entries [%d,%d]: %w", start, end, err)
+		}
+		if len(entries.Entries) == 0 {
+			return fmt.Errorf("get-entries [%d,%d]: returned no entries", start, end)
+		}
+
+		for i, e := range entries.Entries {
+			idx := start + int64(i)
+			rawEntry := ct.LeafEntry{
+				LeafInput: e.LeafInput,
+				ExtraData: e.ExtraData,
+			}
+			key := fmt.Sprintf("%s#%d", logURL, idx)
+			if err := arc.Put(ctx, key, rawEntry); err != nil {
+				lgr.Error("archive_entry_err", "log", logURL, "index", idx, "err", err)
+			}
+			if err := ctmon.ProcessLeafEntry(ctx, lgr, &rawEntry, key, logURL, watchlist, notifiers, dedup); err != nil {
+				lgr.Error("process_leaf_entry_err", "log", logURL, "index", idx, "err", err)
+			}
+		}
+
+		// A log is allowed to return fewer entries than requested; advance
+		// by what actually came back so the checkpoint commit below never
+		// covers an index we didn't process.
+		start += int64(len(entries.Entries))
+	}
+
+	return store.Put(ctx, logCheckpoint{
+		LogURL:   logURL,
+		TreeSize: newSize,
+		RootHash: sth.SHA256RootHash[:],
+	})
+}
+
+// logCheckpoint is the per-log progress record stored in DynamoDB so a
+// restart resumes from the last verified tree size rather than re-fetching
+// a log's entire history.
+type logCheckpoint struct {
+	LogURL   string `dynamodbav:"log_url"`
+	TreeSize int64  `dynamodbav:"tree_size"`
+	RootHash []byte `dynamodbav:"root_hash"`
+}
+
+type checkpointStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+func (s *checkpointStore) Get(ctx context.Context, logURL string) (*logCheckpoint, error) {
+	key, err := attributevalue.MarshalMap(map[string]string{"log_url": logURL})
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var cp logCheckpoint
+	if err := attributevalue.UnmarshalMap(out.Item, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (s *checkpointStore) Put(ctx context.Context, cp logCheckpoint) error {
+	item, err := attributevalue.MarshalMap(cp)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	})
+	return err
+}
@@ -2,24 +2,22 @@ package main
 
 import (
 	"context"
-	"crypto/x509"
 	"encoding/json"
-	"fmt"
+	"io"
 	"log/slog"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	ct "github.com/google/certificate-transparency-go"
-	"github.com/grantae/certinfo"
+	"github.com/psanford/ct-to-slack/internal/ctmon"
 	"github.com/psanford/ssmparam/v2"
-	"github.com/slack-go/slack"
 )
 
 func main() {
@@ -30,6 +28,9 @@ var (
 	prefix = "certs/"
 )
 
+// Handler is invoked by S3 for each new object written under prefix by the
+// CT log ingestion pipeline. It is the original entrypoint; see cmd/ct-tail
+// for the entrypoint that pulls entries directly from CT logs instead.
 func Handler(evt events.S3Event) error {
 	lgr := slog.With()
 	ctx := context.Background()
@@ -41,12 +42,19 @@ func Handler(evt events.S3Event) error {
 	s3client := s3.NewFromConfig(cfg)
 
 	ssmClient := ssm.NewFromConfig(cfg)
-
 	kv := ssmparam.New(ssmClient)
 
-	webhookURL, err := kv.Get("webhook_url")
+	watchlist, notifiers, dedup, err := ctmon.LoadConfig(kv, cfg, lgr)
 	if err != nil {
-		lgr.Error("get_webhook_url_err", "err", err)
+		lgr.Error("load_config_err", "err", err)
+		return err
+	}
+
+	// kekARN is optional: objects written without envelope encryption are
+	// read exactly as before, so existing buckets keep working untouched.
+	var encryptor *ctmon.Encryptor
+	if kekARN, err := kv.Get("kms_key_arn"); err == nil && kekARN != "" {
+		encryptor = ctmon.NewEncryptor(kms.NewFromConfig(cfg), kekARN)
 	}
 
 	for _, rec := range evt.Records {
@@ -70,57 +78,35 @@ func Handler(evt events.S3Event) error {
 			return err
 		}
 
-		dec := json.NewDecoder(resp.Body)
-		var rawEntry ct.LeafEntry
-		err = dec.Decode(&rawEntry)
-		if err != nil {
-			lgr.Error("decode json err", "key", key, "err", err)
-			return err
-		}
-
+		body, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
-
-		logEntry, err := ct.LogEntryFromLeaf(0, &rawEntry)
 		if err != nil {
-			lgr.Error("load log entry leaf err", "key", key, "err", err)
+			lgr.Error("read obj err", "key", key, "err", err)
 			return err
 		}
 
-		if logEntry.X509Cert == nil {
-			lgr.Error("expected x509 cert but got none", "key", key)
-			return nil
+		if ctmon.IsEncrypted(resp.Metadata) {
+			if encryptor == nil {
+				lgr.Error("encrypted obj but no kms_key_arn configured", "key", key)
+				return nil
+			}
+			body, err = encryptor.Decrypt(ctx, body, resp.Metadata)
+			if err != nil {
+				lgr.Error("decrypt obj err", "key", key, "err", err)
+				return err
+			}
 		}
 
-		cert, err := x509.ParseCertificate(logEntry.X509Cert.Raw)
-		if err != nil {
-			lgr.Error("x509 parse err", "key", key, "err", err)
-			return err
-		}
-		certInfoTxt, err := certinfo.CertificateText(cert)
-		if err != nil {
-			lgr.Error("cert txt err", "key", key, "err", err)
+		var rawEntry ct.LeafEntry
+		if err := json.Unmarshal(body, &rawEntry); err != nil {
+			lgr.Error("decode json err", "key", key, "err", err)
 			return err
 		}
 
-		dnsNames := strings.Join(cert.DNSNames, ", ")
-		messageText := fmt.Sprintf("*New Certificate Detected*\n\n"+
-			"*Key:* %s\n"+
-			"*DNS Names:* %s\n"+
-			"*Not Before:* %s\n"+
-			"*Not After:* %s\n\n"+
-			"*Certificate Details:*\n```%s```",
-			key,
-			dnsNames,
-
-			cert.NotBefore.Format(time.RFC3339),
-			cert.NotAfter.Format(time.RFC3339),
-			certInfoTxt)
-
-		err = slack.PostWebhook(webhookURL, &slack.WebhookMessage{
-			Text: messageText,
-		})
-		if err != nil {
-			lgr.Error("slack_webhook_err", "err", err)
+		logSource := rec.S3.Bucket.Name + "/" + prefix
+		if err := ctmon.ProcessLeafEntry(ctx, lgr, &rawEntry, key, logSource, watchlist, notifiers, dedup); err != nil {
+			lgr.Error("process_leaf_entry_err", "key", key, "err", err)
+			return err
 		}
 	}
 
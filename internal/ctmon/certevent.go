@@ -0,0 +1,81 @@
+package ctmon
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CertEvent is the structured representation of a parsed CT log entry that
+// gets handed to every configured Notifier, rather than a single
+// pre-rendered Slack message, so each sink can render its own format. Its
+// fields are populated the same way regardless of whether the entry was a
+// final certificate or a precertificate, so notifiers don't need to
+// special-case either.
+type CertEvent struct {
+	Key       string // S3 object key (or CT log position) the entry came from
+	RawDER    []byte // raw DER bytes of the certificate (or precertificate TBSCertificate)
+	CertInfo  string // certinfo.CertificateText rendering (or the precert equivalent)
+	IsPrecert bool
+
+	CommonName   string
+	DNSNames     []string
+	NotBefore    time.Time
+	NotAfter     time.Time
+	IssuerCN     string
+	SerialHex    string
+	KeyAlgorithm string // e.g. "RSA-2048" or "ECDSA-256"
+	SCTs         []EmbeddedSCT
+
+	LogSource string // CT log URL, or S3 bucket/prefix, the entry was read from
+	Matches   []WatchlistMatch
+}
+
+// RenderMessageText builds the human-readable message shared by the Slack
+// and generic webhook notifiers.
+func RenderMessageText(evt CertEvent) string {
+	var matchLines strings.Builder
+	for _, m := range evt.Matches {
+		fmt.Fprintf(&matchLines, "• `%s` matched watchlist rule `%s` (%s)\n", m.Name, m.Rule, m.Reason)
+	}
+
+	title := "*New Certificate Detected*"
+	if evt.IsPrecert {
+		title = "*New Precertificate Detected*"
+	}
+
+	sctLines := "(none embedded)"
+	if len(evt.SCTs) > 0 {
+		var b strings.Builder
+		for i, sct := range evt.SCTs {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(sct.LogName)
+		}
+		sctLines = b.String()
+	}
+
+	return fmt.Sprintf("%s\n\n"+
+		"*Key:* %s\n"+
+		"*DNS Names:* %s\n"+
+		"*Issuer CN:* %s\n"+
+		"*Serial:* %s\n"+
+		"*Key Algorithm:* %s\n"+
+		"*Not Before:* %s\n"+
+		"*Not After:* %s\n"+
+		"*Embedded SCTs:* %s\n\n"+
+		"*Watchlist Matches:*\n%s\n"+
+		"*Certificate Details:*\n```%s```",
+		title,
+		evt.Key,
+		strings.Join(evt.DNSNames, ", "),
+		evt.IssuerCN,
+		evt.SerialHex,
+		evt.KeyAlgorithm,
+		evt.NotBefore.Format(time.RFC3339),
+		evt.NotAfter.Format(time.RFC3339),
+		sctLines,
+		matchLines.String(),
+		evt.CertInfo)
+}
@@ -0,0 +1,80 @@
+package ctmon
+
+// config.go loads the SSM parameters shared by every entrypoint (watchlist,
+// notifier, and dedup configuration), so the S3 handler and the CT log
+// tailing mode configure themselves identically.
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+const defaultDedupTTL = 24 * time.Hour
+
+// SSMGetter is the subset of ssmparam.Store used by LoadConfig.
+type SSMGetter interface {
+	Get(name string) (string, error)
+}
+
+// LoadConfig reads watchlist_patterns, protected_domains, notifiers, and
+// dedup_table from SSM and returns the compiled Watchlist, Notifier list,
+// and Deduper (nil if dedup_table isn't set). If no `notifiers` blob is
+// configured, it falls back to a single Slack notifier built from
+// webhook_url, so existing deployments keep working untouched.
+func LoadConfig(kv SSMGetter, cfg aws.Config, lgr *slog.Logger) (*Watchlist, []Notifier, *Deduper, error) {
+	watchlistPatterns, err := kv.Get("watchlist_patterns")
+	if err != nil {
+		lgr.Info("get_watchlist_patterns_err", "err", err)
+	}
+	protectedDomains, err := kv.Get("protected_domains")
+	if err != nil {
+		lgr.Info("get_protected_domains_err", "err", err)
+	}
+	watchlist, err := LoadWatchlist(watchlistPatterns, protectedDomains)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("load watchlist: %w", err)
+	}
+
+	notifiersJSON, err := kv.Get("notifiers")
+	if err != nil {
+		lgr.Info("get_notifiers_err", "err", err)
+	}
+	notifiers, err := LoadNotifiers(notifiersJSON, cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("load notifiers: %w", err)
+	}
+	if len(notifiers) == 0 {
+		webhookURL, err := kv.Get("webhook_url")
+		if err != nil {
+			lgr.Error("get_webhook_url_err", "err", err)
+		}
+		notifiers = []Notifier{NewSlackNotifier("slack", map[string]string{"webhook_url": webhookURL})}
+	}
+
+	dedupTable, err := kv.Get("dedup_table")
+	if err != nil || dedupTable == "" {
+		return watchlist, notifiers, nil, nil
+	}
+
+	ttl := defaultDedupTTL
+	if v, err := kv.Get("dedup_ttl_seconds"); err == nil && v != "" {
+		if n, perr := strconv.Atoi(v); perr == nil {
+			ttl = time.Duration(n) * time.Second
+		}
+	}
+
+	var batchWindow time.Duration
+	if v, err := kv.Get("dedup_batch_window_seconds"); err == nil && v != "" {
+		if n, perr := strconv.Atoi(v); perr == nil {
+			batchWindow = time.Duration(n) * time.Second
+		}
+	}
+
+	dedup := NewDeduper(dynamodb.NewFromConfig(cfg), dedupTable, ttl, batchWindow)
+	return watchlist, notifiers, dedup, nil
+}
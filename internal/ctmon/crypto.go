@@ -0,0 +1,140 @@
+package ctmon
+
+// crypto.go implements optional client-side envelope encryption for the
+// JSON leaf entry objects stored under certs/ in S3, for deployments where
+// the bucket is shared or otherwise lives outside the trust boundary.
+//
+// On write, a random 256-bit DEK encrypts the body with AES-256-GCM and is
+// itself wrapped by a KMS key (the KEK); the wrapped DEK, nonce, and
+// algorithm are carried alongside the ciphertext as object metadata. On
+// read, the KEK id needed to unwrap the DEK is resolved from the wrapped
+// ciphertext blob by KMS itself, not from local config, so objects written
+// under a since-rotated KEK still decrypt.
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+const (
+	MetaWrappedDEK = "wrapped-dek"
+	MetaNonce      = "nonce"
+	MetaAlg        = "alg"
+
+	algAES256GCM = "AES256-GCM"
+)
+
+// Encryptor envelope-encrypts and decrypts S3 object bodies under a KMS key
+// encryption key (KEK).
+type Encryptor struct {
+	kms    *kms.Client
+	kekARN string
+}
+
+func NewEncryptor(kmsClient *kms.Client, kekARN string) *Encryptor {
+	return &Encryptor{kms: kmsClient, kekARN: kekARN}
+}
+
+// EncryptedObject is the envelope-encrypted form of an S3 object body, along
+// with the metadata an S3 PutObject call should attach so a later read can
+// decrypt it again.
+type EncryptedObject struct {
+	Ciphertext []byte
+	Metadata   map[string]string
+}
+
+// Encrypt generates a random DEK, AES-256-GCM encrypts plaintext with it,
+// and wraps the DEK under the configured KEK via KMS GenerateDataKey.
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext []byte) (*EncryptedObject, error) {
+	dek, err := e.kms.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(e.kekARN),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kms generate data key: %w", err)
+	}
+
+	gcm, err := newGCM(dek.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("read nonce: %w", err)
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	return &EncryptedObject{
+		Ciphertext: ciphertext,
+		Metadata: map[string]string{
+			MetaWrappedDEK: base64.StdEncoding.EncodeToString(dek.CiphertextBlob),
+			MetaNonce:      base64.StdEncoding.EncodeToString(nonce),
+			MetaAlg:        algAES256GCM,
+		},
+	}, nil
+}
+
+// Decrypt reverses Encrypt. metadata must be the S3 object metadata
+// produced by a prior Encrypt call, keyed without the "x-amz-meta-" prefix
+// (as the SDK returns it on read); prefixed keys are not recognized.
+func (e *Encryptor) Decrypt(ctx context.Context, ciphertext []byte, metadata map[string]string) ([]byte, error) {
+	if metadata[MetaAlg] != algAES256GCM {
+		return nil, fmt.Errorf("unsupported encryption alg %q", metadata[MetaAlg])
+	}
+
+	wrappedDEK, err := base64.StdEncoding.DecodeString(metadata[MetaWrappedDEK])
+	if err != nil {
+		return nil, fmt.Errorf("decode wrapped dek: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(metadata[MetaNonce])
+	if err != nil {
+		return nil, fmt.Errorf("decode nonce: %w", err)
+	}
+
+	// The key id is intentionally not passed here: KMS resolves it from
+	// wrappedDEK's own ciphertext metadata, so a KEK rotation doesn't break
+	// decryption of objects wrapped under the previous key.
+	unwrapped, err := e.kms.Decrypt(ctx, &kms.DecryptInput{CiphertextBlob: wrappedDEK})
+	if err != nil {
+		return nil, fmt.Errorf("kms decrypt dek: %w", err)
+	}
+
+	gcm, err := newGCM(unwrapped.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("gcm open: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("new aes cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("new gcm: %w", err)
+	}
+	return gcm, nil
+}
+
+// IsEncrypted reports whether S3 object metadata indicates the body was
+// written by Encrypt.
+func IsEncrypted(metadata map[string]string) bool {
+	return metadata[MetaWrappedDEK] != ""
+}
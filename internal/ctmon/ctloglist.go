@@ -0,0 +1,46 @@
+package ctmon
+
+// ctloglist.go resolves an SCT's raw LogID to a human-readable log name
+// using ctloglist.json, a trimmed copy of the Google/Apple CT log list
+// (https://www.gstatic.com/ct/log_list/v3/log_list.json) keyed by each
+// log's base64-encoded SHA-256 log ID. It's refreshed periodically by hand
+// rather than fetched at runtime, so the Lambda has no extra network
+// dependency.
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+)
+
+//go:embed ctloglist.json
+var ctLogListJSON []byte
+
+type ctLogListEntry struct {
+	LogID       string `json:"log_id"`
+	Description string `json:"description"`
+}
+
+var ctLogNames = loadCTLogNames()
+
+func loadCTLogNames() map[string]string {
+	var entries []ctLogListEntry
+	if err := json.Unmarshal(ctLogListJSON, &entries); err != nil {
+		// ctloglist.json is bundled at build time; a parse failure here
+		// means the file is corrupt, not a runtime condition to recover
+		// from. Fall back to an empty list so log names are just omitted.
+		return map[string]string{}
+	}
+
+	names := make(map[string]string, len(entries))
+	for _, e := range entries {
+		names[e.LogID] = e.Description
+	}
+	return names
+}
+
+// CTLogName resolves a raw 32-byte SCT LogID to a human-readable log name
+// from the bundled log list, or "" if it isn't recognized.
+func CTLogName(logID [32]byte) string {
+	return ctLogNames[base64.StdEncoding.EncodeToString(logID[:])]
+}
@@ -0,0 +1,204 @@
+package ctmon
+
+// dedup.go suppresses duplicate notifications for the same logical
+// certificate. CT ingestion routinely produces a precert entry and a final
+// cert entry for the same issuance -- often across multiple logs -- which
+// would otherwise post the same cert to every notifier two or three times.
+// Entries are deduplicated by TBSFingerprint (see fingerprint.go), which
+// maps a precert and its corresponding final cert to the same key.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Deduper tracks which TBSCertificate fingerprints have already been
+// notified on, backed by a DynamoDB table with a TTL attribute so records
+// expire on their own.
+type Deduper struct {
+	client      *dynamodb.Client
+	table       string
+	ttl         time.Duration
+	batchWindow time.Duration
+}
+
+func NewDeduper(client *dynamodb.Client, table string, ttl, batchWindow time.Duration) *Deduper {
+	return &Deduper{client: client, table: table, ttl: ttl, batchWindow: batchWindow}
+}
+
+// BatchingEnabled reports whether a batching window is configured. Without
+// one, duplicates are still suppressed but no "also seen in log X" edit is
+// attempted, since that requires a RefNotifier.
+func (d *Deduper) BatchingEnabled() bool {
+	return d.batchWindow > 0
+}
+
+// PriorNotification is one RefNotifier's original notification for a
+// fingerprint that has already been seen, as needed to append a follow-up
+// edit to the right message.
+type PriorNotification struct {
+	Ref  NotificationRef
+	Text string
+}
+
+// storedNotification is PriorNotification's representation inside the
+// dedup record's "notified" map attribute, one entry per notifier name.
+type storedNotification struct {
+	Channel string `dynamodbav:"channel"`
+	Ts      string `dynamodbav:"ts"`
+	Text    string `dynamodbav:"text"`
+}
+
+// MarkResult is the outcome of Mark: either this is the first sighting of a
+// fingerprint (First true), or it's a duplicate, in which case Notified
+// holds whatever RefNotifier messages were saved for it (keyed by notifier
+// Name()) and WithinBatchWindow reports whether the duplicate arrived soon
+// enough after the original to still be worth appending to.
+type MarkResult struct {
+	First             bool
+	Notified          map[string]PriorNotification
+	WithinBatchWindow bool
+}
+
+// Mark records that fingerprint was just seen from logSource. The caller
+// should notify normally on a first sighting (MarkResult.First == true),
+// saving each RefNotifier's message via SaveNotification if batching is
+// enabled. On a duplicate, the caller should only append to the saved
+// messages in MarkResult.Notified, and only if WithinBatchWindow is true.
+func (d *Deduper) Mark(ctx context.Context, fingerprint, logSource string) (MarkResult, error) {
+	now := time.Now()
+	expiresAt := fmt.Sprintf("%d", now.Add(d.ttl).Unix())
+
+	_, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]types.AttributeValue{
+			"fingerprint":     &types.AttributeValueMemberS{Value: fingerprint},
+			"expires_at":      &types.AttributeValueMemberN{Value: expiresAt},
+			"seen_logs":       &types.AttributeValueMemberSS{Value: []string{logSource}},
+			"first_seen_unix": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+			"notified":        &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(fingerprint)"),
+	})
+	if err == nil {
+		return MarkResult{First: true}, nil
+	}
+
+	var condErr *types.ConditionalCheckFailedException
+	if !errors.As(err, &condErr) {
+		return MarkResult{}, fmt.Errorf("put dedup record: %w", err)
+	}
+
+	out, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.table),
+		Key: map[string]types.AttributeValue{
+			"fingerprint": &types.AttributeValueMemberS{Value: fingerprint},
+		},
+		UpdateExpression: aws.String("ADD seen_logs :log SET expires_at = :exp"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":log": &types.AttributeValueMemberSS{Value: []string{logSource}},
+			":exp": &types.AttributeValueMemberN{Value: expiresAt},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		return MarkResult{}, fmt.Errorf("update dedup record: %w", err)
+	}
+
+	firstSeenUnix, err := attrFirstSeenUnix(out.Attributes["first_seen_unix"])
+	if err != nil {
+		return MarkResult{}, err
+	}
+	notified, err := attrNotified(out.Attributes["notified"])
+	if err != nil {
+		return MarkResult{}, err
+	}
+
+	withinWindow := d.BatchingEnabled() && now.Sub(time.Unix(firstSeenUnix, 0)) <= d.batchWindow
+	return MarkResult{First: false, Notified: notified, WithinBatchWindow: withinWindow}, nil
+}
+
+// SaveNotification records notifierName's message (ref + rendered text) for
+// fingerprint, so a later duplicate sighting can append to the right
+// message instead of guessing which RefNotifier it belongs to. It's an
+// atomic update of just that notifier's entry in the "notified" map, so
+// concurrent RefNotifiers saving under the same fingerprint don't clobber
+// each other.
+func (d *Deduper) SaveNotification(ctx context.Context, fingerprint, notifierName string, ref NotificationRef, text string) error {
+	val, err := attributevalue.Marshal(storedNotification{Channel: ref.Channel, Ts: ref.Ts, Text: text})
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+
+	_, err = d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.table),
+		Key: map[string]types.AttributeValue{
+			"fingerprint": &types.AttributeValueMemberS{Value: fingerprint},
+		},
+		UpdateExpression:         aws.String("SET notified.#n = :v"),
+		ExpressionAttributeNames: map[string]string{"#n": notifierName},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":v": val,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("save notification: %w", err)
+	}
+	return nil
+}
+
+// Delete removes fingerprint's dedup record. It's used to undo Mark when a
+// first sighting fails to notify anywhere, so the fingerprint doesn't
+// permanently swallow the alert for the rest of its TTL.
+func (d *Deduper) Delete(ctx context.Context, fingerprint string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.table),
+		Key: map[string]types.AttributeValue{
+			"fingerprint": &types.AttributeValueMemberS{Value: fingerprint},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("delete dedup record: %w", err)
+	}
+	return nil
+}
+
+func attrFirstSeenUnix(av types.AttributeValue) (int64, error) {
+	n, ok := av.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("first_seen_unix attribute missing or wrong type")
+	}
+	v, err := strconv.ParseInt(n.Value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse first_seen_unix: %w", err)
+	}
+	return v, nil
+}
+
+func attrNotified(av types.AttributeValue) (map[string]PriorNotification, error) {
+	m, ok := av.(*types.AttributeValueMemberM)
+	if !ok {
+		return nil, nil
+	}
+
+	notified := make(map[string]PriorNotification, len(m.Value))
+	for name, v := range m.Value {
+		var sn storedNotification
+		if err := attributevalue.Unmarshal(v, &sn); err != nil {
+			return nil, fmt.Errorf("unmarshal notification %q: %w", name, err)
+		}
+		notified[name] = PriorNotification{
+			Ref:  NotificationRef{Channel: sn.Channel, Ts: sn.Ts},
+			Text: sn.Text,
+		}
+	}
+	return notified, nil
+}
@@ -0,0 +1,118 @@
+package ctmon
+
+// fingerprint.go derives the dedup key used to collapse a precertificate
+// and the final certificate it becomes into a single notification. Per RFC
+// 6962 §3.2, a precert's logged TBSCertificate has its poison extension
+// removed and carries no SCT list, while the final cert's TBSCertificate
+// additionally carries the CT Precertificate SCTs extension the CA added
+// after logging -- otherwise the two TBSCertificates are identical.
+// TBSFingerprint strips that extension before hashing so both entry types
+// land on the same fingerprint.
+
+import (
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+)
+
+// TBSFingerprint returns the hex-encoded SHA-256 of a TBSCertificate, with
+// the CT Precertificate SCTs extension (oidExtensionCTSCT) removed first if
+// present. A precert entry's TBSCertificate never carries that extension,
+// so stripping it from a final cert's TBSCertificate before hashing makes
+// the two map to the same fingerprint.
+func TBSFingerprint(tbsDER []byte) string {
+	canonical, err := stripExtension(tbsDER, oidExtensionCTSCT)
+	if err != nil {
+		// Fall back to hashing the TBS as-is: this one cert's precert and
+		// final-cert entries will land on different fingerprints (so dedup
+		// degrades to "no suppression" for it), but processing isn't
+		// blocked on a malformed or unexpected TBSCertificate encoding.
+		canonical = tbsDER
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// stripExtension removes the extension matching oid from a DER-encoded
+// TBSCertificate's extensions field ([3] EXPLICIT SEQUENCE OF Extension),
+// if present, and returns the re-encoded TBSCertificate. It's a no-op,
+// returning tbsDER unchanged, if oid isn't present or the certificate has
+// no extensions field at all.
+func stripExtension(tbsDER []byte, oid asn1.ObjectIdentifier) ([]byte, error) {
+	var outer asn1.RawValue
+	if _, err := asn1.Unmarshal(tbsDER, &outer); err != nil {
+		return nil, fmt.Errorf("unmarshal tbs certificate: %w", err)
+	}
+
+	var fields []asn1.RawValue
+	rest := outer.Bytes
+	for len(rest) > 0 {
+		var field asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &field)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal tbs certificate field: %w", err)
+		}
+		fields = append(fields, field)
+	}
+
+	const extensionsTag = 3 // [3] EXPLICIT Extensions, RFC 5280 §4.1.2.9
+	extIdx := -1
+	for i, f := range fields {
+		if f.Class == asn1.ClassContextSpecific && f.Tag == extensionsTag {
+			extIdx = i
+		}
+	}
+	if extIdx == -1 {
+		return tbsDER, nil
+	}
+
+	var exts []pkix.Extension
+	if _, err := asn1.Unmarshal(fields[extIdx].Bytes, &exts); err != nil {
+		return nil, fmt.Errorf("unmarshal extensions: %w", err)
+	}
+
+	filtered := exts[:0]
+	found := false
+	for _, ext := range exts {
+		if ext.Id.Equal(oid) {
+			found = true
+			continue
+		}
+		filtered = append(filtered, ext)
+	}
+	if !found {
+		return tbsDER, nil
+	}
+
+	extsDER, err := asn1.Marshal(filtered)
+	if err != nil {
+		return nil, fmt.Errorf("marshal extensions: %w", err)
+	}
+	// Clearing FullBytes forces Marshal to re-encode from Class/Tag/Bytes
+	// below instead of replaying the original (now stale) TLV bytes.
+	fields[extIdx].Bytes = extsDER
+	fields[extIdx].FullBytes = nil
+
+	var content []byte
+	for _, f := range fields {
+		b, err := asn1.Marshal(f)
+		if err != nil {
+			return nil, fmt.Errorf("marshal tbs certificate field: %w", err)
+		}
+		content = append(content, b...)
+	}
+
+	rebuilt, err := asn1.Marshal(asn1.RawValue{
+		Class:      outer.Class,
+		Tag:        outer.Tag,
+		IsCompound: true,
+		Bytes:      content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal tbs certificate: %w", err)
+	}
+	return rebuilt, nil
+}
@@ -0,0 +1,20 @@
+package ctmon
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+)
+
+// keyAlgorithmSummary renders a cert's public key as e.g. "RSA-2048" or
+// "ECDSA-256" for display, rather than just the bare algorithm name.
+func keyAlgorithmSummary(algoName string, pub any) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("%s-%d", algoName, k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("%s-%d", algoName, k.Curve.Params().BitSize)
+	default:
+		return algoName
+	}
+}
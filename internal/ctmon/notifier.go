@@ -0,0 +1,112 @@
+package ctmon
+
+// notifier.go defines the Notifier interface and the `notifiers` SSM
+// parameter format used to configure an ordered list of enabled sinks, so
+// the Slack webhook is no longer the only place a CertEvent can go.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Notifier is a destination that a matched CertEvent can be dispatched to.
+// Implementations must be safe to call concurrently.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, evt CertEvent) error
+}
+
+// NotificationRef identifies a previously-sent notification well enough for
+// a RefNotifier to append a follow-up to it later.
+type NotificationRef struct {
+	Channel string
+	Ts      string
+}
+
+// RefNotifier is implemented by notifiers whose underlying API returns a
+// durable reference to the message it just sent (and can edit a message by
+// that reference). An incoming webhook can't do either, so only the
+// bot-token Slack notifier implements this; it's what the dedup layer uses
+// to turn a duplicate cert sighting into an "also seen in log X" edit
+// instead of a brand new message.
+type RefNotifier interface {
+	Notifier
+	NotifyWithRef(ctx context.Context, evt CertEvent) (NotificationRef, error)
+	AppendNote(ctx context.Context, ref NotificationRef, text string) error
+}
+
+// NotifierConfig is one entry of the `notifiers` SSM parameter: an ordered
+// list of enabled sinks and their per-sink parameters.
+type NotifierConfig struct {
+	Type   string            `json:"type"` // "slack", "slack_bot", "webhook", "sns", "ses", or "pagerduty"
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params"`
+}
+
+// LoadNotifiers parses the `notifiers` SSM parameter and builds a Notifier
+// for each enabled entry. cfg is the AWS config used to construct any
+// AWS-backed notifiers (SNS, SES).
+func LoadNotifiers(notifiersJSON string, cfg aws.Config) ([]Notifier, error) {
+	if strings.TrimSpace(notifiersJSON) == "" {
+		return nil, nil
+	}
+
+	var configs []NotifierConfig
+	if err := json.Unmarshal([]byte(notifiersJSON), &configs); err != nil {
+		return nil, fmt.Errorf("parse notifiers: %w", err)
+	}
+
+	notifiers := make([]Notifier, 0, len(configs))
+	for _, c := range configs {
+		n, err := newNotifier(c, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("build notifier %q: %w", c.Name, err)
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+func newNotifier(c NotifierConfig, cfg aws.Config) (Notifier, error) {
+	switch c.Type {
+	case "slack":
+		return NewSlackNotifier(c.Name, c.Params), nil
+	case "slack_bot":
+		return NewSlackBotNotifier(c.Name, c.Params), nil
+	case "webhook":
+		return NewWebhookNotifier(c.Name, c.Params), nil
+	case "sns":
+		return NewSNSNotifier(c.Name, c.Params, cfg), nil
+	case "ses":
+		return NewSESNotifier(c.Name, c.Params, cfg), nil
+	case "pagerduty":
+		return NewPagerDutyNotifier(c.Name, c.Params), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", c.Type)
+	}
+}
+
+// DispatchAll sends evt to every notifier concurrently and waits for all of
+// them to finish. A failure in one notifier does not stop the others; the
+// returned slice is index-aligned with notifiers and nil where Notify
+// succeeded.
+func DispatchAll(ctx context.Context, notifiers []Notifier, evt CertEvent) []error {
+	errs := make([]error, len(notifiers))
+	var wg sync.WaitGroup
+	for i, n := range notifiers {
+		wg.Add(1)
+		go func(i int, n Notifier) {
+			defer wg.Done()
+			if err := n.Notify(ctx, evt); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", n.Name(), err)
+			}
+		}(i, n)
+	}
+	wg.Wait()
+	return errs
+}
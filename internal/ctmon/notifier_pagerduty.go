@@ -0,0 +1,78 @@
+package ctmon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyNotifier triggers a PagerDuty Events v2 alert for a CertEvent.
+// It's meant for high-severity matches (e.g. confirmed typosquats) that
+// need a human paged rather than just a Slack message.
+type PagerDutyNotifier struct {
+	name       string
+	routingKey string
+	severity   string
+}
+
+func NewPagerDutyNotifier(name string, params map[string]string) *PagerDutyNotifier {
+	severity := params["severity"]
+	if severity == "" {
+		severity = "warning"
+	}
+	return &PagerDutyNotifier{name: name, routingKey: params["routing_key"], severity: severity}
+}
+
+func (n *PagerDutyNotifier) Name() string { return n.name }
+
+type pagerDutyEvent struct {
+	RoutingKey  string                `json:"routing_key"`
+	EventAction string                `json:"event_action"`
+	Payload     pagerDutyEventPayload `json:"payload"`
+}
+
+type pagerDutyEventPayload struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+func (n *PagerDutyNotifier) Notify(ctx context.Context, evt CertEvent) error {
+	if n.routingKey == "" {
+		return fmt.Errorf("pagerduty notifier %q missing routing_key param", n.name)
+	}
+
+	body, err := json.Marshal(pagerDutyEvent{
+		RoutingKey:  n.routingKey,
+		EventAction: "trigger",
+		Payload: pagerDutyEventPayload{
+			Summary:  fmt.Sprintf("Watchlist match on %s", evt.Key),
+			Source:   evt.LogSource,
+			Severity: n.severity,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal pagerduty event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pagerDutyEventsURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty events api returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package ctmon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// SESNotifier emails the rendered CertEvent via SES, for teams that route
+// alerts into a shared inbox or ticketing system.
+type SESNotifier struct {
+	name   string
+	from   string
+	to     string
+	client *ses.Client
+}
+
+func NewSESNotifier(name string, params map[string]string, cfg aws.Config) *SESNotifier {
+	return &SESNotifier{
+		name:   name,
+		from:   params["from"],
+		to:     params["to"],
+		client: ses.NewFromConfig(cfg),
+	}
+}
+
+func (n *SESNotifier) Name() string { return n.name }
+
+func (n *SESNotifier) Notify(ctx context.Context, evt CertEvent) error {
+	if n.from == "" || n.to == "" {
+		return fmt.Errorf("ses notifier %q missing from/to param", n.name)
+	}
+
+	text := RenderMessageText(evt)
+	_, err := n.client.SendEmail(ctx, &ses.SendEmailInput{
+		Source:      aws.String(n.from),
+		Destination: &types.Destination{ToAddresses: []string{n.to}},
+		Message: &types.Message{
+			Subject: &types.Content{Data: aws.String(fmt.Sprintf("New certificate: %s", evt.Key))},
+			Body:    &types.Body{Text: &types.Content{Data: aws.String(text)}},
+		},
+	})
+	return err
+}
@@ -0,0 +1,30 @@
+package ctmon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// SlackNotifier posts the rendered CertEvent to a Slack incoming webhook.
+// This is the original (and default) notification path.
+type SlackNotifier struct {
+	name       string
+	webhookURL string
+}
+
+func NewSlackNotifier(name string, params map[string]string) *SlackNotifier {
+	return &SlackNotifier{name: name, webhookURL: params["webhook_url"]}
+}
+
+func (n *SlackNotifier) Name() string { return n.name }
+
+func (n *SlackNotifier) Notify(ctx context.Context, evt CertEvent) error {
+	if n.webhookURL == "" {
+		return fmt.Errorf("slack notifier %q missing webhook_url param", n.name)
+	}
+	return slack.PostWebhook(n.webhookURL, &slack.WebhookMessage{
+		Text: RenderMessageText(evt),
+	})
+}
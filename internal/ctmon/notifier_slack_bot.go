@@ -0,0 +1,54 @@
+package ctmon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// SlackBotNotifier posts via a Slack bot token (chat.postMessage) instead
+// of an incoming webhook. Unlike a webhook, chat.postMessage returns the
+// channel and timestamp of the message it sent, which chat.update can
+// later target -- that's what lets the dedup layer turn a duplicate cert
+// sighting into an edit of the original message instead of a new post.
+type SlackBotNotifier struct {
+	name    string
+	channel string
+	client  *slack.Client
+}
+
+func NewSlackBotNotifier(name string, params map[string]string) *SlackBotNotifier {
+	return &SlackBotNotifier{
+		name:    name,
+		channel: params["channel"],
+		client:  slack.New(params["bot_token"]),
+	}
+}
+
+func (n *SlackBotNotifier) Name() string { return n.name }
+
+func (n *SlackBotNotifier) Notify(ctx context.Context, evt CertEvent) error {
+	_, err := n.NotifyWithRef(ctx, evt)
+	return err
+}
+
+func (n *SlackBotNotifier) NotifyWithRef(ctx context.Context, evt CertEvent) (NotificationRef, error) {
+	if n.channel == "" {
+		return NotificationRef{}, fmt.Errorf("slack bot notifier %q missing channel param", n.name)
+	}
+
+	channel, ts, err := n.client.PostMessageContext(ctx, n.channel, slack.MsgOptionText(RenderMessageText(evt), false))
+	if err != nil {
+		return NotificationRef{}, fmt.Errorf("chat.postMessage: %w", err)
+	}
+	return NotificationRef{Channel: channel, Ts: ts}, nil
+}
+
+func (n *SlackBotNotifier) AppendNote(ctx context.Context, ref NotificationRef, text string) error {
+	_, _, _, err := n.client.UpdateMessageContext(ctx, ref.Channel, ref.Ts, slack.MsgOptionText(text, false))
+	if err != nil {
+		return fmt.Errorf("chat.update: %w", err)
+	}
+	return nil
+}
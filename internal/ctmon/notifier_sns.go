@@ -0,0 +1,41 @@
+package ctmon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// SNSNotifier publishes the rendered CertEvent to an SNS topic, typically
+// fanned out to S3 or SQS for long-term audit trails.
+type SNSNotifier struct {
+	name     string
+	topicARN string
+	client   *sns.Client
+}
+
+func NewSNSNotifier(name string, params map[string]string, cfg aws.Config) *SNSNotifier {
+	return &SNSNotifier{
+		name:     name,
+		topicARN: params["topic_arn"],
+		client:   sns.NewFromConfig(cfg),
+	}
+}
+
+func (n *SNSNotifier) Name() string { return n.name }
+
+func (n *SNSNotifier) Notify(ctx context.Context, evt CertEvent) error {
+	if n.topicARN == "" {
+		return fmt.Errorf("sns notifier %q missing topic_arn param", n.name)
+	}
+
+	text := RenderMessageText(evt)
+	_, err := n.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: &n.topicARN,
+		Subject:  aws.String(fmt.Sprintf("New certificate: %s", evt.Key)),
+		Message:  &text,
+	})
+	return err
+}
@@ -0,0 +1,64 @@
+package ctmon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier POSTs a CertEvent as JSON to an arbitrary HTTP endpoint,
+// e.g. a Mattermost or Discord-compatible incoming webhook.
+type WebhookNotifier struct {
+	name string
+	url  string
+}
+
+func NewWebhookNotifier(name string, params map[string]string) *WebhookNotifier {
+	return &WebhookNotifier{name: name, url: params["url"]}
+}
+
+func (n *WebhookNotifier) Name() string { return n.name }
+
+type webhookPayload struct {
+	Text      string   `json:"text"`
+	Key       string   `json:"key"`
+	DNSNames  []string `json:"dns_names"`
+	IsPrecert bool     `json:"is_precert"`
+	LogSource string   `json:"log_source"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, evt CertEvent) error {
+	if n.url == "" {
+		return fmt.Errorf("webhook notifier %q missing url param", n.name)
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Text:      RenderMessageText(evt),
+		Key:       evt.Key,
+		DNSNames:  evt.DNSNames,
+		IsPrecert: evt.IsPrecert,
+		LogSource: evt.LogSource,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", n.url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package ctmon
+
+// precert.go parses a CT log's raw precertificate entry. RFC 6962 §3.2
+// stores only the TBSCertificate for a precert leaf (no outer Certificate
+// envelope or signature), so it can't be parsed with crypto/x509 directly;
+// certificate-transparency-go's x509 fork provides ParseTBSCertificate for
+// exactly this.
+
+import (
+	"fmt"
+
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+)
+
+// ParsePrecertTBS parses a precert leaf's raw TBSCertificate bytes.
+func ParsePrecertTBS(tbsDER []byte) (*ctx509.Certificate, error) {
+	cert, err := ctx509.ParseTBSCertificate(tbsDER)
+	if err != nil {
+		return nil, fmt.Errorf("parse tbs certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// precertificateText renders a parsed precert TBSCertificate in roughly the
+// same shape certinfo.CertificateText produces for final certs, so the two
+// entry types look consistent in a notified message.
+func precertificateText(cert *ctx509.Certificate) string {
+	return fmt.Sprintf(
+		"Subject: %s\n"+
+			"Issuer: %s\n"+
+			"Serial Number: %s\n"+
+			"Not Before: %s\n"+
+			"Not After: %s\n"+
+			"DNS Names: %v\n"+
+			"Public Key Algorithm: %s\n",
+		cert.Subject,
+		cert.Issuer,
+		cert.SerialNumber.Text(16),
+		cert.NotBefore,
+		cert.NotAfter,
+		cert.DNSNames,
+		cert.PublicKeyAlgorithm.String(),
+	)
+}
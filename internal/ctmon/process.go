@@ -0,0 +1,215 @@
+package ctmon
+
+// process.go holds the parsing/matching/notification pipeline shared by
+// every entrypoint (the S3-triggered Lambda handler and the direct CT log
+// tailing mode), so both stay in sync as new entry types and notifiers are
+// added.
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/grantae/certinfo"
+)
+
+// ProcessLeafEntry parses a raw CT log leaf entry (either a final
+// certificate or a precertificate), checks it against the watchlist, and
+// dispatches a CertEvent to every configured notifier when it matches. key
+// identifies the entry for logging (an S3 object key or a CT log index);
+// logSource identifies where the entry came from. dedup may be nil, in
+// which case every match is notified with no duplicate suppression.
+func ProcessLeafEntry(ctx context.Context, lgr *slog.Logger, rawEntry *ct.LeafEntry, key, logSource string, watchlist *Watchlist, notifiers []Notifier, dedup *Deduper) error {
+	logEntry, err := ct.LogEntryFromLeaf(0, rawEntry)
+	if err != nil {
+		return fmt.Errorf("load log entry leaf: %w", err)
+	}
+
+	var evt CertEvent
+	var tbsDER []byte
+
+	switch {
+	case logEntry.X509Cert != nil:
+		cert, err := x509.ParseCertificate(logEntry.X509Cert.Raw)
+		if err != nil {
+			return fmt.Errorf("x509 parse: %w", err)
+		}
+		certInfoTxt, err := certinfo.CertificateText(cert)
+		if err != nil {
+			return fmt.Errorf("cert txt: %w", err)
+		}
+		scts, err := ExtractEmbeddedSCTs(cert)
+		if err != nil {
+			lgr.Error("extract_scts_err", "key", key, "err", err)
+		}
+
+		tbsDER = cert.RawTBSCertificate
+		evt = CertEvent{
+			RawDER:       logEntry.X509Cert.Raw,
+			CertInfo:     certInfoTxt,
+			CommonName:   cert.Subject.CommonName,
+			DNSNames:     cert.DNSNames,
+			NotBefore:    cert.NotBefore,
+			NotAfter:     cert.NotAfter,
+			IssuerCN:     cert.Issuer.CommonName,
+			SerialHex:    cert.SerialNumber.Text(16),
+			KeyAlgorithm: keyAlgorithmSummary(cert.PublicKeyAlgorithm.String(), cert.PublicKey),
+			SCTs:         scts,
+		}
+
+	case logEntry.Precert != nil:
+		tbs, err := ParsePrecertTBS(logEntry.Precert.TBSCertificate)
+		if err != nil {
+			return fmt.Errorf("parse precert: %w", err)
+		}
+
+		tbsDER = logEntry.Precert.TBSCertificate
+		evt = CertEvent{
+			RawDER:       logEntry.Precert.TBSCertificate,
+			IsPrecert:    true,
+			CertInfo:     precertificateText(tbs),
+			CommonName:   tbs.Subject.CommonName,
+			DNSNames:     tbs.DNSNames,
+			NotBefore:    tbs.NotBefore,
+			NotAfter:     tbs.NotAfter,
+			IssuerCN:     tbs.Issuer.CommonName,
+			SerialHex:    tbs.SerialNumber.Text(16),
+			KeyAlgorithm: keyAlgorithmSummary(tbs.PublicKeyAlgorithm.String(), tbs.PublicKey),
+		}
+
+	default:
+		lgr.Error("expected x509 cert or precertificate but got neither", "key", key)
+		return nil
+	}
+
+	evt.Key = key
+	evt.LogSource = logSource
+
+	candidates := append([]string{evt.CommonName}, evt.DNSNames...)
+	matches := watchlist.Match(candidates)
+	if len(matches) == 0 {
+		lgr.Info("no_watchlist_match", "key", key)
+		return nil
+	}
+	evt.Matches = matches
+
+	if dedup == nil {
+		notifyAll(ctx, lgr, notifiers, evt, key)
+		return nil
+	}
+
+	dedupeAndNotify(ctx, lgr, dedup, notifiers, evt, TBSFingerprint(tbsDER), logSource, key)
+	return nil
+}
+
+// notifyAll dispatches evt to every notifier concurrently, logging (but not
+// returning) any per-notifier failure.
+func notifyAll(ctx context.Context, lgr *slog.Logger, notifiers []Notifier, evt CertEvent, key string) {
+	for i, notifyErr := range DispatchAll(ctx, notifiers, evt) {
+		if notifyErr != nil {
+			lgr.Error("notifier_err", "key", key, "notifier", notifiers[i].Name(), "err", notifyErr)
+		}
+	}
+}
+
+// dedupeAndNotify suppresses a repeat notification for a cert already seen
+// under fingerprint (e.g. its precert and final-cert entries, or the same
+// cert crossing multiple logs). On the first sighting it notifies normally
+// and, if a batching window is configured, records each RefNotifier's own
+// message so a later duplicate can append to the right one instead of
+// posting again.
+func dedupeAndNotify(ctx context.Context, lgr *slog.Logger, dedup *Deduper, notifiers []Notifier, evt CertEvent, fingerprint, logSource, key string) {
+	result, err := dedup.Mark(ctx, fingerprint, logSource)
+	if err != nil {
+		// Fail open: a dedup outage shouldn't silently drop a real alert.
+		lgr.Error("dedup_mark_err", "key", key, "fingerprint", fingerprint, "err", err)
+		notifyAll(ctx, lgr, notifiers, evt, key)
+		return
+	}
+
+	if result.First {
+		notifyFirstSighting(ctx, lgr, dedup, notifiers, evt, fingerprint, key)
+		return
+	}
+
+	lgr.Info("dedup_suppressed", "key", key, "fingerprint", fingerprint, "log_source", logSource)
+	if !dedup.BatchingEnabled() || !result.WithinBatchWindow {
+		return
+	}
+
+	for _, n := range notifiers {
+		rn, ok := n.(RefNotifier)
+		if !ok {
+			continue
+		}
+		prior, ok := result.Notified[n.Name()]
+		if !ok {
+			continue
+		}
+
+		note := fmt.Sprintf("%s\n\n_Also seen in log:_ `%s`", prior.Text, logSource)
+		if err := rn.AppendNote(ctx, prior.Ref, note); err != nil {
+			lgr.Error("dedup_append_note_err", "key", key, "notifier", n.Name(), "err", err)
+			continue
+		}
+		if err := dedup.SaveNotification(ctx, fingerprint, n.Name(), prior.Ref, note); err != nil {
+			lgr.Error("dedup_save_notification_err", "key", key, "notifier", n.Name(), "err", err)
+		}
+	}
+}
+
+// notifyFirstSighting dispatches evt to every notifier concurrently, saving
+// each RefNotifier's message under fingerprint so a later duplicate can find
+// it. If every notifier fails, the dedup record for fingerprint is deleted
+// rather than left in place: otherwise a cert that never actually alerted
+// would stay silently suppressed for the rest of the dedup TTL.
+func notifyFirstSighting(ctx context.Context, lgr *slog.Logger, dedup *Deduper, notifiers []Notifier, evt CertEvent, fingerprint, key string) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	anySucceeded := false
+
+	for _, n := range notifiers {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+
+			rn, ok := n.(RefNotifier)
+			if !ok {
+				if err := n.Notify(ctx, evt); err != nil {
+					lgr.Error("notifier_err", "key", key, "notifier", n.Name(), "err", err)
+					return
+				}
+				mu.Lock()
+				anySucceeded = true
+				mu.Unlock()
+				return
+			}
+
+			ref, err := rn.NotifyWithRef(ctx, evt)
+			if err != nil {
+				lgr.Error("notifier_err", "key", key, "notifier", n.Name(), "err", err)
+				return
+			}
+			mu.Lock()
+			anySucceeded = true
+			mu.Unlock()
+
+			if dedup.BatchingEnabled() {
+				if err := dedup.SaveNotification(ctx, fingerprint, n.Name(), ref, RenderMessageText(evt)); err != nil {
+					lgr.Error("dedup_save_notification_err", "key", key, "notifier", n.Name(), "err", err)
+				}
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if !anySucceeded {
+		lgr.Error("dedup_first_sighting_notify_failed", "key", key, "fingerprint", fingerprint)
+		if err := dedup.Delete(ctx, fingerprint); err != nil {
+			lgr.Error("dedup_delete_err", "key", key, "fingerprint", fingerprint, "err", err)
+		}
+	}
+}
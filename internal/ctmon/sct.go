@@ -0,0 +1,73 @@
+package ctmon
+
+// sct.go extracts and decodes the SCT list a CA embeds in a final
+// certificate's CT Precertificate SCTs extension (RFC 6962 §3.3), so the
+// notified message can show which logs actually vouched for the cert
+// instead of burying that in the raw certinfo text block.
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// oidExtensionCTSCT is the X.509v3 extension OID a CA embeds the final
+// cert's SCT list under (1.3.6.1.4.1.11129.2.4.2, RFC 6962 §3.3).
+var oidExtensionCTSCT = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// EmbeddedSCT is one decoded signed certificate timestamp, with its log
+// name resolved from the bundled CT log list where possible.
+type EmbeddedSCT struct {
+	LogName   string
+	Timestamp time.Time
+}
+
+// ExtractEmbeddedSCTs finds and decodes the SCT list embedded in cert's CT
+// Precertificate SCTs extension, if present. It returns (nil, nil) when the
+// extension is absent, which is normal for precertificates.
+func ExtractEmbeddedSCTs(cert *x509.Certificate) ([]EmbeddedSCT, error) {
+	var extValue []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidExtensionCTSCT) {
+			extValue = ext.Value
+			break
+		}
+	}
+	if extValue == nil {
+		return nil, nil
+	}
+
+	// The extension value is an ASN.1 OCTET STRING wrapping the
+	// TLS-encoded SignedCertificateTimestampList.
+	var sctListBytes []byte
+	if _, err := asn1.Unmarshal(extValue, &sctListBytes); err != nil {
+		return nil, fmt.Errorf("asn1 unwrap sct extension: %w", err)
+	}
+
+	sctList, err := ct.DeserializeSCTList(sctListBytes)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize sct list: %w", err)
+	}
+
+	scts := make([]EmbeddedSCT, 0, len(sctList))
+	for _, tlsSCT := range sctList {
+		sct, err := ct.DeserializeSCT(bytes.NewReader(tlsSCT.Val))
+		if err != nil {
+			return nil, fmt.Errorf("deserialize sct: %w", err)
+		}
+
+		name := CTLogName(sct.LogID.KeyID)
+		if name == "" {
+			name = fmt.Sprintf("unknown log (%x)", sct.LogID.KeyID[:8])
+		}
+		scts = append(scts, EmbeddedSCT{
+			LogName:   name,
+			Timestamp: ct.TimestampToTime(sct.Timestamp),
+		})
+	}
+	return scts, nil
+}
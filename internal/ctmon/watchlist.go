@@ -0,0 +1,355 @@
+package ctmon
+
+// watchlist.go implements the domain watchlist used to decide which
+// certificates are worth a Slack notification. A certificate is only
+// reported when one of its DNS names (or its CN) matches a configured
+// watchlist entry: a literal suffix match, an RE2 regex, or a
+// confusable/typosquat match against a protected domain.
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// WatchlistMatchMode selects how a WatchlistEntry.Pattern is interpreted.
+type WatchlistMatchMode string
+
+const (
+	MatchLiteral   WatchlistMatchMode = "literal"
+	MatchRegex     WatchlistMatchMode = "regex"
+	MatchTyposquat WatchlistMatchMode = "typosquat"
+)
+
+// WatchlistEntry is one rule loaded from the `watchlist_patterns` SSM
+// parameter. For MatchTyposquat entries, Pattern is the protected domain
+// (e.g. "example.com") that candidate names are compared against.
+type WatchlistEntry struct {
+	Pattern string             `json:"pattern"`
+	Mode    WatchlistMatchMode `json:"mode"`
+
+	re *regexp.Regexp
+}
+
+// Watchlist is the compiled form of the watchlist_patterns/protected_domains
+// SSM parameters, ready to be matched against a certificate's DNS names.
+type Watchlist struct {
+	entries []WatchlistEntry
+}
+
+// maxTyposquatDistance is the maximum Damerau-Levenshtein distance between a
+// candidate label and a protected domain label that is still considered a
+// typosquat rather than an unrelated domain.
+const maxTyposquatDistance = 2
+
+// minTyposquatLabelLen is the shortest protected label that distance-based
+// matching (DL distance, keyboard-adjacent swap) is applied to. Below this,
+// maxTyposquatDistance covers most of the possible string space -- a label
+// like "ab" is within distance 2 of nearly any other short label -- so
+// shorter labels are only matched by literal/confusable rules.
+const minTyposquatLabelLen = 4
+
+// LoadWatchlist parses the `watchlist_patterns` JSON array (explicit
+// literal/regex rules) and the `protected_domains` JSON array (bare domains
+// that are additionally checked for typosquats) into a compiled Watchlist.
+func LoadWatchlist(patternsJSON, protectedDomainsJSON string) (*Watchlist, error) {
+	var entries []WatchlistEntry
+	if strings.TrimSpace(patternsJSON) != "" {
+		if err := json.Unmarshal([]byte(patternsJSON), &entries); err != nil {
+			return nil, fmt.Errorf("parse watchlist_patterns: %w", err)
+		}
+	}
+
+	if strings.TrimSpace(protectedDomainsJSON) != "" {
+		var protected []string
+		if err := json.Unmarshal([]byte(protectedDomainsJSON), &protected); err != nil {
+			return nil, fmt.Errorf("parse protected_domains: %w", err)
+		}
+		for _, d := range protected {
+			entries = append(entries, WatchlistEntry{Pattern: strings.ToLower(d), Mode: MatchLiteral})
+			entries = append(entries, WatchlistEntry{Pattern: strings.ToLower(d), Mode: MatchTyposquat})
+		}
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		switch e.Mode {
+		case MatchRegex:
+			re, err := regexp.Compile(e.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("compile watchlist regex %q: %w", e.Pattern, err)
+			}
+			e.re = re
+		case MatchLiteral, MatchTyposquat:
+			e.Pattern = strings.ToLower(e.Pattern)
+		default:
+			return nil, fmt.Errorf("unknown watchlist mode %q for pattern %q", e.Mode, e.Pattern)
+		}
+	}
+
+	return &Watchlist{entries: entries}, nil
+}
+
+// WatchlistMatch describes why a candidate DNS name tripped the watchlist.
+type WatchlistMatch struct {
+	Name   string // the DNS name (or CN) that matched
+	Rule   string // the watchlist pattern that matched
+	Reason string // "exact", "regex", "typosquat-distance", "keyboard-adjacent", or "confusable"
+}
+
+// Match evaluates every candidate name against the watchlist and returns one
+// WatchlistMatch per hit. Names are deduplicated by (name, rule) pair.
+func (wl *Watchlist) Match(names []string) []WatchlistMatch {
+	var matches []WatchlistMatch
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		norm, err := normalizeDomainName(name)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range wl.entries {
+			var reason string
+			switch e.Mode {
+			case MatchLiteral:
+				if norm == e.Pattern || strings.HasSuffix(norm, "."+e.Pattern) {
+					reason = "exact"
+				}
+			case MatchRegex:
+				if e.re.MatchString(norm) {
+					reason = "regex"
+				}
+			case MatchTyposquat:
+				reason = matchTyposquat(norm, e.Pattern)
+			}
+
+			if reason == "" {
+				continue
+			}
+			key := name + "|" + e.Pattern
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			matches = append(matches, WatchlistMatch{Name: name, Rule: e.Pattern, Reason: reason})
+		}
+	}
+
+	return matches
+}
+
+// normalizeDomainName lower-cases name, decodes any punycode (xn--) labels,
+// and trims a trailing root dot, so it can be compared against watchlist
+// entries and protected domains on equal footing.
+func normalizeDomainName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	if name == "" {
+		return "", fmt.Errorf("empty domain name")
+	}
+	unicodeName, err := idna.ToUnicode(name)
+	if err != nil {
+		// Not a valid punycode label (or not punycode at all); fall back to
+		// the lower-cased name as-is rather than dropping the candidate.
+		return name, nil
+	}
+	return unicodeName, nil
+}
+
+// matchTyposquat reports whether candidate is a likely typosquat of
+// protected, and if so, which reason ("keyboard-adjacent",
+// "typosquat-distance", or "confusable") to surface. It strips the public
+// suffix from both sides first so "example.co.uk" and "examp1e.co.uk" are
+// compared on their registrable label, not the whole name.
+func matchTyposquat(candidate, protected string) string {
+	candidateLabel := registrableLabel(candidate)
+	protectedLabel := registrableLabel(protected)
+	if candidateLabel == "" || protectedLabel == "" || candidateLabel == protectedLabel {
+		return ""
+	}
+
+	if foldConfusables(candidateLabel) == foldConfusables(protectedLabel) {
+		return "confusable"
+	}
+
+	// Below minTyposquatLabelLen, distance-based matching has too little
+	// signal in the protected label to be worth applying.
+	if len([]rune(protectedLabel)) < minTyposquatLabelLen {
+		return ""
+	}
+
+	// Checked before the general DL distance below since a keyboard-adjacent
+	// single-key swap always has DL distance 1 and would otherwise always be
+	// reported as the less specific "typosquat-distance".
+	if keyboardAdjacentSwap(candidateLabel, protectedLabel) {
+		return "keyboard-adjacent"
+	}
+
+	if damerauLevenshtein(candidateLabel, protectedLabel) <= maxTyposquatDistance {
+		return "typosquat-distance"
+	}
+
+	return ""
+}
+
+// registrableLabel returns the leftmost label of a domain's registrable part
+// (the public-suffix-stripped eTLD+1), e.g. "login.example.co.uk" -> "example".
+func registrableLabel(domain string) string {
+	etldPlusOne, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		// Not a recognized public suffix (e.g. a bare label); use the
+		// whole string so single-label watchlist entries still match.
+		etldPlusOne = domain
+	}
+	label := etldPlusOne
+	if i := strings.IndexByte(etldPlusOne, '.'); i >= 0 {
+		label = etldPlusOne[:i]
+	}
+	return label
+}
+
+// confusables maps commonly-confused Unicode runes (Cyrillic/Greek
+// lookalikes, digit/letter substitutions) to the ASCII letter they are used
+// to impersonate. It is not exhaustive; it covers the confusables actually
+// seen in the wild against brand domains.
+var confusables = map[rune]rune{
+	'а': 'a', // Cyrillic а (U+0430)
+	'е': 'e', // Cyrillic е (U+0435)
+	'о': 'o', // Cyrillic о (U+043E)
+	'р': 'p', // Cyrillic р (U+0440)
+	'с': 'c', // Cyrillic с (U+0441)
+	'у': 'y', // Cyrillic у (U+0443)
+	'х': 'x', // Cyrillic х (U+0445)
+	'і': 'i', // Cyrillic і (U+0456)
+	'ο': 'o', // Greek omicron (U+03BF)
+	'α': 'a', // Greek alpha (U+03B1)
+	'ρ': 'p', // Greek rho (U+03C1)
+	'0': 'o',
+	'1': 'l',
+	'3': 'e',
+	'5': 's',
+}
+
+// foldConfusables maps every confusable rune in s to its ASCII lookalike.
+func foldConfusables(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if ascii, ok := confusables[r]; ok {
+			r = ascii
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// keyboardRows groups QWERTY keys that are horizontally adjacent, so a typo
+// like "exanple" (n next to m) can be told apart from an unrelated domain.
+var keyboardRows = []string{
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+}
+
+var keyboardNeighbors = buildKeyboardNeighbors()
+
+func buildKeyboardNeighbors() map[rune]map[rune]bool {
+	neighbors := make(map[rune]map[rune]bool)
+	for _, row := range keyboardRows {
+		runes := []rune(row)
+		for i, r := range runes {
+			set := neighbors[r]
+			if set == nil {
+				set = make(map[rune]bool)
+				neighbors[r] = set
+			}
+			if i > 0 {
+				set[runes[i-1]] = true
+			}
+			if i < len(runes)-1 {
+				set[runes[i+1]] = true
+			}
+		}
+	}
+	return neighbors
+}
+
+// keyboardAdjacentSwap reports whether a and b are identical in length and
+// differ in exactly one position, where the two differing characters are
+// adjacent keys on a QWERTY keyboard (e.g. "exanple" vs "example" swapping
+// "m" for the adjacent "n").
+func keyboardAdjacentSwap(a, b string) bool {
+	ar, br := []rune(a), []rune(b)
+	if len(ar) != len(br) {
+		return false
+	}
+
+	diffIdx := -1
+	for i := range ar {
+		if ar[i] != br[i] {
+			if diffIdx != -1 {
+				return false
+			}
+			diffIdx = i
+		}
+	}
+	if diffIdx == -1 {
+		return false
+	}
+
+	return keyboardNeighbors[ar[diffIdx]][br[diffIdx]]
+}
+
+// damerauLevenshtein returns the Damerau-Levenshtein edit distance between a
+// and b (insertions, deletions, substitutions, and adjacent transpositions
+// all cost 1), which is what catches both "examle.com" (deletion) and
+// "examlpe.com" (transposition) as close to "example.com".
+func damerauLevenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	la, lb := len(ar), len(br)
+
+	d := make([][]int, la+1)
+	for i := range d {
+		d[i] = make([]int, lb+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= lb; j++ {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= la; i++ {
+		for j := 1; j <= lb; j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(
+				d[i-1][j]+1,
+				d[i][j-1]+1,
+				d[i-1][j-1]+cost,
+			)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				if t := d[i-2][j-2] + 1; t < d[i][j] {
+					d[i][j] = t
+				}
+			}
+		}
+	}
+
+	return d[la][lb]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}